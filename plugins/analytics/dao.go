@@ -0,0 +1,196 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/bulkindexer"
+	"github.com/appbaseio/arc/util/esclient"
+	"github.com/appbaseio/arc/util/escursor"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// pitKeepAlive is how long a point-in-time stays alive between pages.
+const pitKeepAlive = "1m"
+
+// userAgentMapping is merged into the analytics index mapping so the
+// user_agent.* fields populated by the useragent package are searchable and
+// aggregatable rather than landing as unmapped/text fields.
+const userAgentMapping = `{
+	"properties": {
+		"user_agent": {
+			"properties": {
+				"browser_family": {"type": "keyword"},
+				"browser_version": {"type": "keyword"},
+				"os_family": {"type": "keyword"},
+				"os_version": {"type": "keyword"},
+				"device_type": {"type": "keyword"}
+			}
+		}
+	}
+}`
+
+type elasticsearch struct {
+	indexName string
+	bulk      *bulkindexer.Processor
+	client    esclient.Client
+}
+
+func newElasticsearch(indexName string) (*elasticsearch, error) {
+	client, err := util.GetClient()
+	if err != nil {
+		return nil, fmt.Errorf("error while resolving elasticsearch client: %v", err)
+	}
+
+	// The bulk processor is specific to olivere/elastic/v7; against ES6/8
+	// clusters records are sent one at a time through esclient.Client's own
+	// Bulk instead (see indexRecord).
+	var bulk *bulkindexer.Processor
+	if util.GetVersion() == 7 {
+		bulk, err = bulkindexer.New(context.Background(), util.GetClient7(), logTag+"-bulk", bulkindexer.DefaultConfig())
+		if err != nil {
+			return nil, fmt.Errorf("error while starting bulk indexer: %v", err)
+		}
+	}
+
+	if err := client.PutMapping(context.Background(), indexName, userAgentMapping); err != nil {
+		return nil, fmt.Errorf("error while updating index mapping for user_agent fields: %v", err)
+	}
+
+	return &elasticsearch{indexName, bulk, client}, nil
+}
+
+// indexRecord buffers rec for indexing. Against ES7 it's handed to the
+// shared bulk indexer (batched, retried with backoff); against ES6/ES8 it's
+// sent through esclient.Client's own Bulk. ES8 removed mapping types, so
+// Type is only set when talking to ES6.
+func (es *elasticsearch) indexRecord(ctx context.Context, docID string, rec map[string]interface{}) {
+	if es.bulk != nil {
+		bulkIndex := es7.NewBulkIndexRequest().
+			Index(es.indexName).
+			Id(docID).
+			Doc(rec)
+		es.bulk.Add(bulkIndex)
+		return
+	}
+
+	req := esclient.BulkRequest{Op: esclient.BulkIndex, Index: es.indexName, ID: docID, Doc: rec}
+	if util.GetVersion() == 6 {
+		req.Type = "_doc"
+	}
+	if _, err := es.client.Bulk(ctx, []esclient.BulkRequest{req}); err != nil {
+		log.Errorln(logTag, ": error indexing analytics record :", err)
+	}
+}
+
+// updateRecord merges rec into the existing analytics record for docID
+// instead of replacing it, for events (click/conversion/custom events) that
+// add fields to an already-indexed record rather than create a new one;
+// indexRecord's full Doc() index would otherwise wipe the fields recorded
+// on the original search.
+func (es *elasticsearch) updateRecord(ctx context.Context, docID string, rec map[string]interface{}) {
+	if es.bulk != nil {
+		bulkUpdate := es7.NewBulkUpdateRequest().
+			Index(es.indexName).
+			Id(docID).
+			Doc(rec)
+		es.bulk.Add(bulkUpdate)
+		return
+	}
+
+	req := esclient.BulkRequest{Op: esclient.BulkUpdate, Index: es.indexName, ID: docID, Doc: rec}
+	if util.GetVersion() == 6 {
+		req.Type = "_doc"
+	}
+	if _, err := es.client.Bulk(ctx, []esclient.BulkRequest{req}); err != nil {
+		log.Errorln(logTag, ": error updating analytics record :", err)
+	}
+}
+
+// Close drains the bulk indexer, flushing any buffered records.
+func (es *elasticsearch) Close() error {
+	if es.bulk == nil {
+		return nil
+	}
+	return es.bulk.Close()
+}
+
+// recordHit and recordPage are the wire shape returned by getRecordsCursor,
+// normalized from esclient.SearchResponse.
+type recordHit struct {
+	ID     string          `json:"id"`
+	Source json.RawMessage `json:"source"`
+}
+
+type recordPage struct {
+	Took      int64       `json:"took"`
+	TotalHits int64       `json:"total_hits"`
+	Hits      []recordHit `json:"hits"`
+}
+
+// getRecordsCursor is the analytics equivalent of the logs package's deep
+// pagination: it pages analytics records with a Point-In-Time plus
+// search_after instead of from/size, so it keeps working past the 10k
+// window. Pass an empty cursor for the first page, and feed back the
+// returned cursor for subsequent ones; the returned cursor is empty once the
+// last page has been returned. It goes through es.client (esclient.Client),
+// so it works against any cluster version that supports PIT (ES7.10+, ES8).
+func (es *elasticsearch) getRecordsCursor(ctx context.Context, cursor, size, filter string) ([]byte, string, error) {
+	s, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, "", fmt.Errorf(`invalid value "%v" for query param "size"`, size)
+	}
+
+	state, err := escursor.Decode(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if state.PITID == "" {
+		state.PITID, err = escursor.Open(ctx, es.client, pitKeepAlive, es.indexName)
+		if err != nil {
+			return nil, "", fmt.Errorf("error opening point-in-time: %v", err)
+		}
+	}
+
+	resp, err := es.client.Search(ctx, esclient.SearchRequest{
+		Query:       filter,
+		Size:        s,
+		Sort:        []string{"_shard_doc"},
+		SearchAfter: state.SearchAfter,
+		PITID:       state.PITID,
+	})
+	if err != nil {
+		escursor.Close(ctx, es.client, state.PITID)
+		return nil, "", fmt.Errorf("error while running point-in-time search: %v", err)
+	}
+
+	page := recordPage{Took: resp.Took, TotalHits: resp.TotalHits}
+	for _, h := range resp.Hits {
+		page.Hits = append(page.Hits, recordHit{ID: h.ID, Source: h.Source})
+	}
+	raw, err := json.Marshal(page)
+	if err != nil {
+		escursor.Close(ctx, es.client, state.PITID)
+		return nil, "", err
+	}
+
+	if len(resp.Hits) < s {
+		escursor.Close(ctx, es.client, state.PITID)
+		return raw, "", nil
+	}
+
+	nextCursor, err := escursor.Encode(escursor.Cursor{
+		PITID:       state.PITID,
+		SearchAfter: resp.Hits[len(resp.Hits)-1].Sort,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return raw, nextCursor, nil
+}
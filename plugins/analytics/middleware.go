@@ -2,8 +2,9 @@ package analytics
 
 import (
 	"bytes"
-	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -23,6 +24,7 @@ import (
 	"github.com/appbaseio-confidential/arc/plugins/logs"
 	"github.com/appbaseio-confidential/arc/util"
 	"github.com/appbaseio-confidential/arc/util/iplookup"
+	"github.com/appbaseio-confidential/arc/util/useragent"
 	"github.com/google/uuid"
 )
 
@@ -35,6 +37,14 @@ const (
 	XSearchClickPosition = "X-Search-Click-Position"
 	XSearchConversion    = "X-Search-Conversion"
 	XSearchCustomEvent   = "X-Search-Custom-Event"
+	// XSearchMeta carries a base64-encoded JSON-marshaled AnalyticsEvent as
+	// a single-header alternative to the X-Search-Filters/Click/Click-Position/
+	// Conversion/Custom-Event headers above. The individual headers keep
+	// working for one release for existing integrations.
+	XSearchMeta = "X-Search-Meta"
+	// XCursor carries the deep-pagination cursor for GET /_analytics; it's
+	// set on the response when there's another page to fetch.
+	XCursor = "X-Cursor"
 )
 
 type chain struct {
@@ -138,7 +148,7 @@ func (a *Analytics) recorder(h http.HandlerFunc) http.HandlerFunc {
 		w.Write(respRecorder.Body.Bytes())
 
 		// record the search response
-		go a.recordResponse(docID, searchID, respRecorder, r)
+		submitRecord(recordJob{docID: docID, searchID: searchID, body: respRecorder, req: r})
 	}
 }
 
@@ -209,11 +219,6 @@ func (a *Analytics) recordResponse(docID, searchID string, w *httptest.ResponseR
 		record["hits_in_response"] = hits
 		record["total_hits"] = esResponse.Hits.Total
 		record["timestamp"] = time.Now().Format(time.RFC3339)
-
-		searchFilters := parse(r.Header.Get(XSearchFilters))
-		if len(searchFilters) > 0 {
-			record["search_filters"] = searchFilters
-		}
 	}
 
 	ipAddr := iplookup.FromRequest(r)
@@ -234,42 +239,165 @@ func (a *Analytics) recordResponse(docID, searchID string, w *httptest.ResponseR
 		record["country"] = country
 	}
 
-	searchClick := r.Header.Get(XSearchClick)
-	if searchClick != "" {
+	uaInfo := useragent.Instance().Parse(r.UserAgent())
+	record["user_agent"] = uaInfo
+
+	event, err := parseAnalyticsEvent(r)
+	if err != nil {
+		log.Printf("%s: error parsing analytics event, falling back to response without it: %v\n", logTag, err)
+	} else {
+		buildRecord(record, event)
+	}
+
+	// TODO: remove
+	//logRaw(record)
+	if searchID == "" {
+		// first time this search is recorded: create the full record.
+		a.es.indexRecord(r.Context(), docID, record)
+	} else {
+		// a click/conversion/custom-event follow-up on an existing search:
+		// merge into it instead of replacing it wholesale.
+		a.es.updateRecord(r.Context(), docID, record)
+	}
+}
+
+// AnalyticsEvent is the typed schema for the interaction signals a client
+// can attach to a search. It can be supplied either via the legacy
+// X-Search-* headers or as a single X-Search-Meta header (or the body of
+// the companion POST /_analytics/{search_id} endpoint) carrying this
+// struct as JSON; both paths are normalized into a record by buildRecord.
+type AnalyticsEvent struct {
+	Filters       map[string]string      `json:"filters,omitempty"`
+	CustomEvents  map[string]interface{} `json:"custom_events,omitempty"`
+	Click         bool                   `json:"click,omitempty"`
+	ClickPosition int                    `json:"click_position,omitempty"`
+	Conversion    bool                   `json:"conversion,omitempty"`
+	Hits          []Hit                  `json:"hits,omitempty"`
+}
+
+// Hit is a single client-reported search result, e.g. one the user clicked.
+type Hit struct {
+	ID     string `json:"id"`
+	Type   string `json:"type,omitempty"`
+	Source string `json:"source,omitempty"`
+}
+
+// buildRecord merges event's fields into record using the same keys the
+// legacy header-parsing path used to write directly, so both paths produce
+// identical records regardless of which one a client used.
+func buildRecord(record map[string]interface{}, event AnalyticsEvent) {
+	if len(event.Filters) > 0 {
+		record["search_filters"] = event.Filters
+	}
+	if event.Click {
+		record["click"] = event.Click
+	}
+	if event.ClickPosition != 0 {
+		record["click_position"] = event.ClickPosition
+	}
+	if event.Conversion {
+		record["conversion"] = event.Conversion
+	}
+	if len(event.CustomEvents) > 0 {
+		record["custom_events"] = event.CustomEvents
+	}
+	if len(event.Hits) > 0 {
+		record["hits"] = event.Hits
+	}
+}
+
+// parseAnalyticsEvent builds an AnalyticsEvent from the request: the
+// X-Search-Meta header, when present, wins; otherwise it's assembled from
+// the legacy X-Search-* headers. X-Search-Meta carries a base64-encoded
+// JSON-marshaled AnalyticsEvent so existing proxies that only forward
+// whitelisted headers only need to whitelist one more.
+func parseAnalyticsEvent(r *http.Request) (AnalyticsEvent, error) {
+	if raw := r.Header.Get(XSearchMeta); raw != "" {
+		return decodeAnalyticsEvent(raw)
+	}
+	return eventFromLegacyHeaders(r), nil
+}
+
+func decodeAnalyticsEvent(encoded string) (AnalyticsEvent, error) {
+	var event AnalyticsEvent
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return event, fmt.Errorf("invalid %s header: %v", XSearchMeta, err)
+	}
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return event, fmt.Errorf("invalid %s header: %v", XSearchMeta, err)
+	}
+	return event, nil
+}
+
+func eventFromLegacyHeaders(r *http.Request) AnalyticsEvent {
+	var event AnalyticsEvent
+
+	if searchFilters := parse(r.Header.Get(XSearchFilters)); len(searchFilters) > 0 {
+		event.Filters = searchFilters
+	}
+
+	if customEvents := parse(r.Header.Get(XSearchCustomEvent)); len(customEvents) > 0 {
+		converted := make(map[string]interface{}, len(customEvents))
+		for k, v := range customEvents {
+			converted[k] = v
+		}
+		event.CustomEvents = converted
+	}
+
+	if searchClick := r.Header.Get(XSearchClick); searchClick != "" {
 		if clicked, err := strconv.ParseBool(searchClick); err == nil {
-			record["click"] = clicked
+			event.Click = clicked
 		} else {
 			log.Printf("%s: invalid bool value '%v' passed for header %s: %v\n",
 				logTag, searchClick, XSearchClick, err)
 		}
 	}
 
-	searchClickPosition := r.Header.Get(XSearchClickPosition)
-	if searchClickPosition != "" {
+	if searchClickPosition := r.Header.Get(XSearchClickPosition); searchClickPosition != "" {
 		if pos, err := strconv.Atoi(searchClickPosition); err == nil {
-			record["click_position"] = pos
+			event.ClickPosition = pos
 		} else {
 			log.Printf("%s: invalid int value '%v' passed for header %s: %v\n",
 				logTag, searchClickPosition, XSearchClickPosition, err)
 		}
 	}
 
-	searchConversion := r.Header.Get(XSearchConversion)
-	if searchConversion != "" {
+	if searchConversion := r.Header.Get(XSearchConversion); searchConversion != "" {
 		if conversion, err := strconv.ParseBool(searchConversion); err == nil {
-			record["conversion"] = conversion
+			event.Conversion = conversion
 		} else {
 			log.Printf("%s: invalid bool value '%v' passed for header %s: %v\n",
 				logTag, searchConversion, XSearchConversion, err)
 		}
 	}
 
-	customEvents := parse(r.Header.Get(XSearchCustomEvent))
-	if len(customEvents) > 0 {
-		record["custom_events"] = customEvents
+	return event
+}
+
+// RecordEvent handles the companion POST /_analytics/{search_id} endpoint:
+// clients that would rather not encode AnalyticsEvent as a header can POST
+// it as a plain JSON body against the search_id returned by the original
+// search response's X-Search-Id header. It's wired up alongside the other
+// analytics routes in the plugin's Routes().
+func (a *Analytics) RecordEvent(w http.ResponseWriter, r *http.Request) {
+	searchID := strings.TrimPrefix(r.URL.Path, "/_analytics/")
+	if searchID == "" || searchID == r.URL.Path {
+		util.WriteBackError(w, "missing search_id in path", http.StatusBadRequest)
+		return
 	}
 
-	// TODO: remove
-	//logRaw(record)
-	a.es.indexRecord(context.Background(), docID, record)
+	var event AnalyticsEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		util.WriteBackError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	record := make(map[string]interface{})
+	record["timestamp"] = time.Now().Format(time.RFC3339)
+	buildRecord(record, event)
+
+	a.es.updateRecord(r.Context(), searchID, record)
+	w.WriteHeader(http.StatusOK)
 }
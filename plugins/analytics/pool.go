@@ -0,0 +1,102 @@
+package analytics
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// recordTimeout bounds how long a single recordResponse call may run;
+	// without it, a stuck Elasticsearch cluster pins down goroutines (and
+	// the response bodies they hold) indefinitely.
+	recordTimeout = 5 * time.Second
+	// recordQueueSize bounds how many recordResponse jobs can be queued
+	// before new ones are dropped instead of blocking the request path.
+	recordQueueSize = 1000
+	// recordWorkers is the number of goroutines draining the record queue.
+	recordWorkers = 4
+)
+
+// recordJob is a deferred call to (*Analytics).recordResponse.
+type recordJob struct {
+	docID    string
+	searchID string
+	body     *httptest.ResponseRecorder
+	req      *http.Request
+}
+
+var (
+	recordQueue    chan recordJob
+	recordBaseCtx  context.Context
+	recordCancel   context.CancelFunc
+	recordPoolOnce sync.Once
+	recordDropped  uint64
+)
+
+// recordPool lazily starts the bounded worker pool that drains recordQueue,
+// so plugin init order doesn't matter.
+func recordPool() chan recordJob {
+	recordPoolOnce.Do(func() {
+		recordBaseCtx, recordCancel = context.WithCancel(context.Background())
+		recordQueue = make(chan recordJob, recordQueueSize)
+		for i := 0; i < recordWorkers; i++ {
+			go runRecordWorker(recordQueue)
+		}
+	})
+	return recordQueue
+}
+
+func runRecordWorker(queue chan recordJob) {
+	for job := range queue {
+		recordWithDeadline(job)
+	}
+}
+
+func recordWithDeadline(job recordJob) {
+	// Derive from the request's own context, not recordBaseCtx directly:
+	// WithContext replaces the whole context tree, so deriving from
+	// recordBaseCtx would drop everything upstream middleware stashed on
+	// the request (e.g. what index.FromContext/category.FromContext read).
+	ctx, cancel := context.WithTimeout(job.req.Context(), recordTimeout)
+	defer cancel()
+
+	// Still abort early on pool shutdown, since the timeout alone won't.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-recordBaseCtx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	Instance().recordResponse(job.docID, job.searchID, job.body, job.req.WithContext(ctx))
+}
+
+// submitRecord enqueues a recordResponse job. If the queue is full the job
+// is dropped and counted rather than blocking the request path.
+func submitRecord(job recordJob) {
+	select {
+	case recordPool() <- job:
+	default:
+		atomic.AddUint64(&recordDropped, 1)
+		log.Printf("%s: record queue full (%d), dropping analytics record for search %s\n",
+			logTag, recordQueueSize, job.docID)
+	}
+}
+
+// Close cancels the package-level base context, aborting any in-flight
+// recordResponse calls, and flushes the analytics bulk indexer. It should
+// be called once on plugin shutdown.
+func (a *Analytics) Close() error {
+	if recordCancel != nil {
+		recordCancel()
+	}
+	return a.es.Close()
+}
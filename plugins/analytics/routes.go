@@ -0,0 +1,50 @@
+package analytics
+
+import (
+	"net/http"
+
+	"github.com/appbaseio-confidential/arc/util"
+	"github.com/appbaseio/arc/plugins"
+)
+
+// Routes returns the HTTP routes this plugin adds on top of the search
+// recorder middleware: the companion event endpoint served by RecordEvent,
+// and cursor-based read access to the recorded events themselves.
+func (a *Analytics) Routes() []plugins.Route {
+	return []plugins.Route{
+		{
+			Name:        "record analytics event",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_analytics/{search_id}",
+			HandlerFunc: a.RecordEvent,
+		},
+		{
+			Name:        "get analytics records",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_analytics",
+			HandlerFunc: a.getRecords,
+		},
+	}
+}
+
+// getRecords serves GET /_analytics: it pages recorded analytics events via
+// getRecordsCursor. Pass no ?cursor= for the first page; the response's
+// X-Cursor header, when present, carries the cursor for the next one.
+func (a *Analytics) getRecords(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	size := q.Get("size")
+	if size == "" {
+		size = "100"
+	}
+
+	body, nextCursor, err := a.es.getRecordsCursor(r.Context(), q.Get("cursor"), size, q.Get("filter"))
+	if err != nil {
+		util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if nextCursor != "" {
+		w.Header().Set(XCursor, nextCursor)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -2,26 +2,51 @@ package logs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/bulkindexer"
+	"github.com/appbaseio/arc/util/esclient"
+	"github.com/appbaseio/arc/util/escursor"
 	es7 "github.com/olivere/elastic/v7"
 )
 
+// pitKeepAlive is how long a point-in-time stays alive between pages; the
+// client is expected to fetch the next page well within this window.
+const pitKeepAlive = "1m"
+
 type elasticsearch struct {
 	indexName string
+	bulk      *bulkindexer.Processor
+	client    esclient.Client
 }
 
 func initPlugin(indexName, config string) (*elasticsearch, error) {
 	ctx := context.Background()
 
-	var es = &elasticsearch{indexName}
+	client, err := util.GetClient()
+	if err != nil {
+		return nil, fmt.Errorf("error while resolving elasticsearch client: %v", err)
+	}
+
+	// The bulk processor is specific to olivere/elastic/v7; against ES6/8
+	// clusters records are sent one at a time through esclient.Client's own
+	// Bulk instead, without buffering (see indexRecord).
+	var bulk *bulkindexer.Processor
+	if util.GetVersion() == 7 {
+		bulk, err = bulkindexer.New(ctx, util.GetClient7(), logTag+"-bulk", bulkindexer.DefaultConfig())
+		if err != nil {
+			return nil, fmt.Errorf("error while starting bulk indexer: %v", err)
+		}
+	}
+
+	var es = &elasticsearch{indexName, bulk, client}
 	// Check if meta index already exists
-	exists, err := util.GetClient7().IndexExists(indexName).
-		Do(ctx)
+	exists, err := client.IndexExists(ctx, indexName)
 	if err != nil {
 		return nil, fmt.Errorf("error while checking if index already exists: %v", err)
 	}
@@ -38,10 +63,7 @@ func initPlugin(indexName, config string) (*elasticsearch, error) {
 	settings := fmt.Sprintf(config, nodes, nodes-1)
 
 	// Meta index doesn't exist, create one
-	_, err = util.GetClient7().CreateIndex(indexName).
-		Body(settings).
-		Do(ctx)
-	if err != nil {
+	if err := client.CreateIndex(ctx, indexName, settings); err != nil {
 		return nil, fmt.Errorf("error while creating index named \"%s\"", indexName)
 	}
 
@@ -49,20 +71,37 @@ func initPlugin(indexName, config string) (*elasticsearch, error) {
 	return es, nil
 }
 
+// indexRecord buffers rec for indexing. Against ES7 it's handed to the
+// shared bulk indexer (batched, retried with backoff); against ES6/ES8 it's
+// sent through esclient.Client's own Bulk, one record at a time, since
+// BulkProcessorService is v7-specific. ES8 removed mapping types, so Type
+// is only set when talking to ES6.
 func (es *elasticsearch) indexRecord(ctx context.Context, rec record) {
-	bulkIndex := es7.NewBulkIndexRequest().
-		Index(es.indexName).
-		Type("_doc").
-		Doc(rec)
-
-	_, err := util.GetClient7().Bulk().
-		Add(bulkIndex).
-		Do(ctx)
-	if err != nil {
+	if es.bulk != nil {
+		bulkIndex := es7.NewBulkIndexRequest().
+			Index(es.indexName).
+			Doc(rec)
+		es.bulk.Add(bulkIndex)
+		return
+	}
+
+	req := esclient.BulkRequest{Op: esclient.BulkIndex, Index: es.indexName, Doc: rec}
+	if util.GetVersion() == 6 {
+		req.Type = "_doc"
+	}
+	if _, err := es.client.Bulk(ctx, []esclient.BulkRequest{req}); err != nil {
 		log.Errorln(logTag, ": error indexing log record :", err)
 	}
 }
 
+// Close drains the bulk indexer, flushing any buffered records.
+func (es *elasticsearch) Close() error {
+	if es.bulk == nil {
+		return nil
+	}
+	return es.bulk.Close()
+}
+
 func (es *elasticsearch) getRawLogs(ctx context.Context, from, size, filter string, indices ...string) ([]byte, error) {
 	offset, err := strconv.Atoi(from)
 	if err != nil {
@@ -79,3 +118,93 @@ func (es *elasticsearch) getRawLogs(ctx context.Context, from, size, filter stri
 		return es.getRawLogsES7(ctx, from, s, filter, offset, indices...)
 	}
 }
+
+// cursorHit and cursorPage are the wire shape returned by getRawLogsCursor,
+// normalized from esclient.SearchResponse.
+type cursorHit struct {
+	ID     string          `json:"id"`
+	Source json.RawMessage `json:"source"`
+}
+
+type cursorPage struct {
+	Took      int64       `json:"took"`
+	TotalHits int64       `json:"total_hits"`
+	Hits      []cursorHit `json:"hits"`
+}
+
+// getRawLogsCursor is the deep-pagination counterpart to getRawLogs: it uses
+// a Point-In-Time instead of from/size, so it keeps working past the 10k
+// window that from/size hits. Pass an empty cursor for the first page; feed
+// back the returned cursor for subsequent pages. The returned cursor is
+// empty once the last page has been returned, at which point the PIT has
+// already been closed. It goes through es.client (esclient.Client), so it
+// works against any cluster version that supports PIT (ES7.10+, ES8); ES6
+// callers should keep using getRawLogs.
+func (es *elasticsearch) getRawLogsCursor(ctx context.Context, cursor, size, filter string, indices ...string) ([]byte, string, error) {
+	s, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, "", fmt.Errorf(`invalid value "%v" for query param "size"`, size)
+	}
+
+	state, err := escursor.Decode(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if state.PITID == "" {
+		state.PITID, err = escursor.Open(ctx, es.client, pitKeepAlive, indices...)
+		if err != nil {
+			return nil, "", fmt.Errorf("error opening point-in-time: %v", err)
+		}
+	}
+
+	resp, err := es.client.Search(ctx, esclient.SearchRequest{
+		Query:       filter,
+		Size:        s,
+		Sort:        []string{"_shard_doc"},
+		SearchAfter: state.SearchAfter,
+		PITID:       state.PITID,
+	})
+	if err != nil {
+		escursor.Close(ctx, es.client, state.PITID)
+		return nil, "", fmt.Errorf("error while running point-in-time search: %v", err)
+	}
+
+	page := cursorPage{Took: resp.Took, TotalHits: resp.TotalHits}
+	for _, h := range resp.Hits {
+		page.Hits = append(page.Hits, cursorHit{ID: h.ID, Source: h.Source})
+	}
+	raw, err := json.Marshal(page)
+	if err != nil {
+		escursor.Close(ctx, es.client, state.PITID)
+		return nil, "", err
+	}
+
+	if len(resp.Hits) < s {
+		// last page: release the PIT so ES can drop the held segments.
+		escursor.Close(ctx, es.client, state.PITID)
+		return raw, "", nil
+	}
+
+	nextCursor, err := escursor.Encode(escursor.Cursor{
+		PITID:       state.PITID,
+		SearchAfter: resp.Hits[len(resp.Hits)-1].Sort,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return raw, nextCursor, nil
+}
+
+// getLogs is the single entry point HTTP routes should call: it dispatches
+// to getRawLogsCursor when the caller supplied a cursor (or is asking for
+// one, i.e. didn't pass from), and otherwise keeps the from/size path
+// of getRawLogs for ES6 back-compat. The returned cursor is always "" on
+// the from/size path.
+func (es *elasticsearch) getLogs(ctx context.Context, from, size, cursor, filter string, indices ...string) ([]byte, string, error) {
+	if cursor != "" || from == "" {
+		return es.getRawLogsCursor(ctx, cursor, size, filter, indices...)
+	}
+	raw, err := es.getRawLogs(ctx, from, size, filter, indices...)
+	return raw, "", err
+}
@@ -0,0 +1,35 @@
+package logs
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// xCursor carries the deep-pagination cursor for ServeLogs; it's set on
+// the response when there's another page to fetch.
+const xCursor = "X-Cursor"
+
+// ServeLogs is the HTTP handler for reading recorded logs: ?cursor= pages
+// through getLogs' Point-In-Time path, and ?from= (with an optional ?size=)
+// keeps using the from/size path for ES6 back-compat; a request with neither
+// ?cursor= nor ?from= starts a new Point-In-Time. Whatever registers this plugin's
+// routes should wire this to the logs read endpoint.
+func (es *elasticsearch) ServeLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	size := q.Get("size")
+	if size == "" {
+		size = "100"
+	}
+
+	body, nextCursor, err := es.getLogs(r.Context(), q.Get("from"), size, q.Get("cursor"), q.Get("filter"), q["index"]...)
+	if err != nil {
+		util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if nextCursor != "" {
+		w.Header().Set(xCursor, nextCursor)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
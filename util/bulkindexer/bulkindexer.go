@@ -0,0 +1,252 @@
+// Package bulkindexer provides a shared, retrying bulk-indexing subsystem on
+// top of elastic/v7's BulkProcessorService. It is used by plugins (analytics,
+// logs, ...) that previously issued one ad-hoc bulk request per incoming
+// record, which falls over under load and silently drops data on ES hiccups.
+package bulkindexer
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	es7 "github.com/olivere/elastic/v7"
+	log "github.com/sirupsen/logrus"
+)
+
+const logTag = "[bulkindexer]"
+
+// Config controls batching, concurrency and retry behavior of a Processor.
+type Config struct {
+	// FlushInterval is the maximum time a record can sit in the buffer
+	// before being flushed, regardless of BulkActions/BulkSize.
+	FlushInterval time.Duration
+	// BulkActions is the number of records that triggers a flush.
+	BulkActions int
+	// BulkSize is the accumulated request size in bytes that triggers a flush.
+	BulkSize int
+	// Workers is the number of concurrent bulk-flush workers.
+	Workers int
+	// MaxRetries is the number of times a failed item is retried before
+	// it's dropped for good.
+	MaxRetries int
+	// InitialBackoff is the base delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential backoff delay.
+	MaxBackoff time.Duration
+}
+
+// DefaultConfig returns sane defaults, each of which can be overridden by the
+// matching ARC_BULKINDEXER_* environment variable.
+func DefaultConfig() Config {
+	cfg := Config{
+		FlushInterval:  1 * time.Second,
+		BulkActions:    500,
+		BulkSize:       5 << 20, // 5MB
+		Workers:        2,
+		MaxRetries:     5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+	}
+	if v, ok := envDuration("ARC_BULKINDEXER_FLUSH_INTERVAL"); ok {
+		cfg.FlushInterval = v
+	}
+	if v, ok := envInt("ARC_BULKINDEXER_BULK_ACTIONS"); ok {
+		cfg.BulkActions = v
+	}
+	if v, ok := envInt("ARC_BULKINDEXER_BULK_SIZE"); ok {
+		cfg.BulkSize = v
+	}
+	if v, ok := envInt("ARC_BULKINDEXER_WORKERS"); ok {
+		cfg.Workers = v
+	}
+	if v, ok := envInt("ARC_BULKINDEXER_MAX_RETRIES"); ok {
+		cfg.MaxRetries = v
+	}
+	if v, ok := envDuration("ARC_BULKINDEXER_INITIAL_BACKOFF"); ok {
+		cfg.InitialBackoff = v
+	}
+	if v, ok := envDuration("ARC_BULKINDEXER_MAX_BACKOFF"); ok {
+		cfg.MaxBackoff = v
+	}
+	return cfg
+}
+
+func envInt(name string) (int, bool) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return 0, false
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Warnln(logTag, ": invalid int value for", name, ":", err)
+		return 0, false
+	}
+	return v, true
+}
+
+func envDuration(name string) (time.Duration, bool) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return 0, false
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Warnln(logTag, ": invalid duration value for", name, ":", err)
+		return 0, false
+	}
+	return v, true
+}
+
+// Processor wraps an elastic.BulkProcessor with exponential-backoff retry of
+// failed items and a clean Close for shutdown.
+type Processor struct {
+	name string
+	cfg  Config
+	bp   *es7.BulkProcessor
+
+	mu sync.Mutex
+	// retries is keyed by the request itself (es7.BulkableRequest wraps a
+	// pointer, so it's a valid, unique map key per in-flight request) rather
+	// than by response item id/index. Keying by response data breaks down
+	// for whole-batch failures, where there is no per-item response to key
+	// on at all.
+	retries map[es7.BulkableRequest]int
+}
+
+// New starts a named bulk processor against client using cfg.
+func New(ctx context.Context, client *es7.Client, name string, cfg Config) (*Processor, error) {
+	p := &Processor{
+		name:    name,
+		cfg:     cfg,
+		retries: make(map[es7.BulkableRequest]int),
+	}
+
+	bp, err := client.BulkProcessor().
+		Name(name).
+		Workers(cfg.Workers).
+		BulkActions(cfg.BulkActions).
+		BulkSize(cfg.BulkSize).
+		FlushInterval(cfg.FlushInterval).
+		Before(p.before).
+		After(p.after).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.bp = bp
+
+	return p, nil
+}
+
+// Add enqueues a request for the next flush.
+func (p *Processor) Add(req es7.BulkableRequest) {
+	p.bp.Add(req)
+}
+
+// Close flushes any buffered requests and stops the worker goroutines.
+func (p *Processor) Close() error {
+	return p.bp.Close()
+}
+
+func (p *Processor) before(executionID int64, requests []es7.BulkableRequest) {
+	log.Debugln(logTag, ": flushing", len(requests), "requests, execution", executionID, "processor", p.name)
+}
+
+func (p *Processor) after(executionID int64, requests []es7.BulkableRequest, response *es7.BulkResponse, err error) {
+	if err != nil {
+		log.Errorln(logTag, ": bulk request failed, execution", executionID, ":", err)
+		p.retryAll(requests)
+		return
+	}
+	if response == nil {
+		return
+	}
+	failed := response.Failed()
+	if len(failed) == 0 {
+		p.forgetRequests(requests)
+		return
+	}
+	log.Errorln(logTag, ":", len(failed), "items failed in execution", executionID)
+	p.retryFailed(requests, response.Items)
+}
+
+// forgetRequests drops any retry bookkeeping for requests that ultimately
+// succeeded, so the retries map doesn't grow unboundedly over the life of
+// the processor.
+func (p *Processor) forgetRequests(requests []es7.BulkableRequest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, req := range requests {
+		delete(p.retries, req)
+	}
+}
+
+func (p *Processor) retryAll(requests []es7.BulkableRequest) {
+	for _, req := range requests {
+		p.retryRequest(req)
+	}
+}
+
+// retryFailed walks requests and their corresponding per-position response
+// items together (Bulk preserves request/response ordering), retrying the
+// ones that failed and forgetting the retry bookkeeping for the ones that
+// finally succeeded.
+func (p *Processor) retryFailed(requests []es7.BulkableRequest, items []map[string]*es7.BulkResponseItem) {
+	for i, req := range requests {
+		if i >= len(items) {
+			break
+		}
+		if itemFailed(items[i]) {
+			p.retryRequest(req)
+		} else {
+			p.mu.Lock()
+			delete(p.retries, req)
+			p.mu.Unlock()
+		}
+	}
+}
+
+func itemFailed(item map[string]*es7.BulkResponseItem) bool {
+	for _, r := range item {
+		if r.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// retryRequest schedules req for another attempt, keyed on req itself so
+// every request in a batch (even a whole-batch failure with no per-item
+// response) gets its own retry budget.
+func (p *Processor) retryRequest(req es7.BulkableRequest) {
+	p.mu.Lock()
+	attempt := p.retries[req]
+	p.retries[req] = attempt + 1
+	p.mu.Unlock()
+
+	if attempt >= p.cfg.MaxRetries {
+		log.Errorln(logTag, ": giving up on request after", attempt, "retries:", req.String())
+		p.mu.Lock()
+		delete(p.retries, req)
+		p.mu.Unlock()
+		return
+	}
+
+	delay := backoff(p.cfg.InitialBackoff, p.cfg.MaxBackoff, attempt)
+	go func() {
+		time.Sleep(delay)
+		p.bp.Add(req)
+	}()
+}
+
+// backoff computes an exponential delay with full jitter, capped at max.
+func backoff(initial, max time.Duration, attempt int) time.Duration {
+	d := initial * time.Duration(1<<uint(attempt))
+	if d <= 0 || d > max {
+		d = max
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
@@ -0,0 +1,120 @@
+package bulkindexer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	es7 "github.com/olivere/elastic/v7"
+)
+
+func TestBackoff(t *testing.T) {
+	initial := 100 * time.Millisecond
+	max := 2 * time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		for i := 0; i < 50; i++ {
+			d := backoff(initial, max, attempt)
+			if d < 0 || d > max {
+				t.Fatalf("attempt %d: backoff %v out of [0, %v]", attempt, d, max)
+			}
+		}
+	}
+}
+
+func TestBackoffCapsAtMax(t *testing.T) {
+	// A large enough attempt count overflows the exponent; backoff should
+	// fall back to max before jittering, so the result stays in [0, max]
+	// instead of going negative.
+	d := backoff(100*time.Millisecond, time.Second, 63)
+	if d < 0 || d > time.Second {
+		t.Fatalf("got %v, want a value in [0, 1s]", d)
+	}
+}
+
+func TestItemFailed(t *testing.T) {
+	cases := []struct {
+		name string
+		item map[string]*es7.BulkResponseItem
+		want bool
+	}{
+		{"no actions", map[string]*es7.BulkResponseItem{}, false},
+		{"succeeded", map[string]*es7.BulkResponseItem{
+			"index": {Status: 201},
+		}, false},
+		{"failed", map[string]*es7.BulkResponseItem{
+			"index": {Status: 500, Error: &es7.ErrorDetails{Reason: "boom"}},
+		}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := itemFailed(c.item); got != c.want {
+				t.Errorf("itemFailed(%+v) = %v, want %v", c.item, got, c.want)
+			}
+		})
+	}
+}
+
+func TestEnvInt(t *testing.T) {
+	const name = "ARC_BULKINDEXER_TEST_INT"
+	defer os.Unsetenv(name)
+
+	if _, ok := envInt(name); ok {
+		t.Fatalf("expected ok=false when unset")
+	}
+
+	os.Setenv(name, "42")
+	v, ok := envInt(name)
+	if !ok || v != 42 {
+		t.Fatalf("envInt() = %v, %v, want 42, true", v, ok)
+	}
+
+	os.Setenv(name, "not-a-number")
+	if _, ok := envInt(name); ok {
+		t.Fatalf("expected ok=false for invalid int")
+	}
+}
+
+func TestRetriesKeyedByRequestIdentityNotContent(t *testing.T) {
+	// Two distinct requests with identical content (as every request in a
+	// whole-batch failure has, since there's no per-item response to tell
+	// them apart) must still get independent retry budgets.
+	a := es7.NewBulkIndexRequest().Index("i").Id("")
+	b := es7.NewBulkIndexRequest().Index("i").Id("")
+
+	p := &Processor{retries: make(map[es7.BulkableRequest]int)}
+	p.retries[a] = 3
+	p.retries[b] = 1
+
+	if p.retries[a] != 3 || p.retries[b] != 1 {
+		t.Fatalf("requests with identical content collided: a=%d b=%d, want 3, 1", p.retries[a], p.retries[b])
+	}
+
+	p.forgetRequests([]es7.BulkableRequest{a})
+	if _, ok := p.retries[a]; ok {
+		t.Errorf("forgetRequests did not remove a")
+	}
+	if p.retries[b] != 1 {
+		t.Errorf("forgetRequests removed b's bookkeeping too: got %d, want 1", p.retries[b])
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	const name = "ARC_BULKINDEXER_TEST_DURATION"
+	defer os.Unsetenv(name)
+
+	if _, ok := envDuration(name); ok {
+		t.Fatalf("expected ok=false when unset")
+	}
+
+	os.Setenv(name, "5s")
+	v, ok := envDuration(name)
+	if !ok || v != 5*time.Second {
+		t.Fatalf("envDuration() = %v, %v, want 5s, true", v, ok)
+	}
+
+	os.Setenv(name, "not-a-duration")
+	if _, ok := envDuration(name); ok {
+		t.Fatalf("expected ok=false for invalid duration")
+	}
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/appbaseio/arc/util/esclient"
+)
+
+// GetClient returns the esclient.Client matching the cluster version
+// detected at startup (GetVersion), so plugins can target ES6/7/8 through
+// one interface instead of branching on the version themselves. Plugins
+// that specifically need olivere/elastic features unavailable through
+// esclient.Client (e.g. the v7 bulk processor) can keep using GetClient7
+// directly.
+func GetClient() (esclient.Client, error) {
+	switch v := GetVersion(); v {
+	case 6:
+		return esclient.NewV6(GetClient6()), nil
+	case 7:
+		return esclient.NewV7(GetClient7()), nil
+	case 8:
+		return esclient.NewV8(GetClient8()), nil
+	default:
+		return nil, fmt.Errorf("util: unsupported elasticsearch major version %d", v)
+	}
+}
@@ -0,0 +1,94 @@
+// Package esclient defines a version-agnostic Elasticsearch client surface
+// so plugins don't have to branch on util.GetVersion() themselves. Each
+// supported cluster major version (6, 7, 8) gets its own implementation;
+// util.GetClient() picks the right one at startup based on the detected
+// cluster version.
+package esclient
+
+import "context"
+
+// BulkOp is the action a BulkRequest performs.
+type BulkOp string
+
+const (
+	BulkIndex  BulkOp = "index"
+	BulkCreate BulkOp = "create"
+	BulkUpdate BulkOp = "update"
+	BulkDelete BulkOp = "delete"
+)
+
+// BulkRequest is a single operation in a Bulk call. Type is only meaningful
+// against ES6, which still has mapping types; it's ignored by the ES7/ES8
+// implementations.
+type BulkRequest struct {
+	Op    BulkOp
+	Index string
+	Type  string
+	ID    string
+	Doc   interface{}
+}
+
+// BulkResponseItem reports the outcome of a single BulkRequest.
+type BulkResponseItem struct {
+	ID     string
+	Index  string
+	Status int
+	Error  string
+}
+
+// BulkResponse is the outcome of a Bulk call.
+type BulkResponse struct {
+	Items  []BulkResponseItem
+	Failed []BulkResponseItem
+}
+
+// SearchRequest is a version-agnostic search. PITID/SearchAfter are only
+// honored against ES7/ES8; passing them against ES6 returns an error since
+// ES6 has no Point-In-Time API.
+type SearchRequest struct {
+	Indices     []string
+	Query       string // a query_string query, or "" to match all
+	From        int
+	Size        int
+	Sort        []string
+	SearchAfter []interface{}
+	PITID       string
+}
+
+// Hit is a single search result, normalized across client versions.
+type Hit struct {
+	ID     string
+	Type   string
+	Source []byte
+	Sort   []interface{}
+}
+
+// SearchResponse is a version-agnostic search result.
+type SearchResponse struct {
+	Took      int64
+	TotalHits int64
+	Hits      []Hit
+}
+
+// PITResponse carries the id returned by an OpenPIT call.
+type PITResponse struct {
+	ID string
+}
+
+// Client is the surface every plugin should use instead of reaching for a
+// specific olivere/go-elasticsearch client directly.
+type Client interface {
+	IndexExists(ctx context.Context, index string) (bool, error)
+	CreateIndex(ctx context.Context, index, body string) error
+	// PutMapping merges body (a mapping definition) into index's existing
+	// mapping, for fields added to an index after it was first created.
+	PutMapping(ctx context.Context, index, body string) error
+	Bulk(ctx context.Context, requests []BulkRequest) (*BulkResponse, error)
+	Search(ctx context.Context, req SearchRequest) (*SearchResponse, error)
+	// OpenPIT and ClosePIT implement Elasticsearch's Point-In-Time API.
+	// Only supported against ES7.10+ and ES8; ES6 implementations return
+	// an error.
+	OpenPIT(ctx context.Context, keepAlive string, indices ...string) (*PITResponse, error)
+	ClosePIT(ctx context.Context, id string) error
+	DeleteByQuery(ctx context.Context, index, query string) error
+}
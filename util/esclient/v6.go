@@ -0,0 +1,122 @@
+package esclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	es6 "gopkg.in/olivere/elastic.v6"
+)
+
+const defaultType6 = "_doc"
+
+// v6Client implements Client on top of olivere/elastic.v6. ES6 still has
+// mapping types and predates the Point-In-Time API.
+type v6Client struct {
+	client *es6.Client
+}
+
+// NewV6 wraps an existing *elastic.Client (v6) as a Client.
+func NewV6(client *es6.Client) Client {
+	return &v6Client{client: client}
+}
+
+func (c *v6Client) IndexExists(ctx context.Context, index string) (bool, error) {
+	return c.client.IndexExists(index).Do(ctx)
+}
+
+func (c *v6Client) CreateIndex(ctx context.Context, index, body string) error {
+	_, err := c.client.CreateIndex(index).Body(body).Do(ctx)
+	return err
+}
+
+func (c *v6Client) PutMapping(ctx context.Context, index, body string) error {
+	_, err := c.client.PutMapping().Index(index).Type(defaultType6).BodyString(body).Do(ctx)
+	return err
+}
+
+func (c *v6Client) Bulk(ctx context.Context, requests []BulkRequest) (*BulkResponse, error) {
+	svc := c.client.Bulk()
+	for _, req := range requests {
+		typ := req.Type
+		if typ == "" {
+			typ = defaultType6
+		}
+		switch req.Op {
+		case BulkDelete:
+			svc = svc.Add(es6.NewBulkDeleteRequest().Index(req.Index).Type(typ).Id(req.ID))
+		case BulkUpdate:
+			svc = svc.Add(es6.NewBulkUpdateRequest().Index(req.Index).Type(typ).Id(req.ID).Doc(req.Doc))
+		default:
+			r := es6.NewBulkIndexRequest().Index(req.Index).Type(typ).Doc(req.Doc)
+			if req.ID != "" {
+				r = r.Id(req.ID)
+			}
+			svc = svc.Add(r)
+		}
+	}
+
+	resp, err := svc.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &BulkResponse{}
+	for _, item := range resp.Items {
+		for _, r := range item {
+			bri := BulkResponseItem{ID: r.Id, Index: r.Index, Status: r.Status}
+			if r.Error != nil {
+				bri.Error = r.Error.Reason
+				out.Failed = append(out.Failed, bri)
+			}
+			out.Items = append(out.Items, bri)
+		}
+	}
+	return out, nil
+}
+
+func (c *v6Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	if req.PITID != "" || len(req.SearchAfter) > 0 {
+		return nil, fmt.Errorf("esclient: point-in-time pagination is not supported against ES6")
+	}
+
+	query := es6.NewBoolQuery()
+	if req.Query != "" {
+		query = query.Filter(es6.NewQueryStringQuery(req.Query))
+	}
+
+	svc := c.client.Search(req.Indices...).Query(query).From(req.From).Size(req.Size)
+	for _, s := range req.Sort {
+		svc = svc.Sort(s, true)
+	}
+
+	resp, err := svc.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &SearchResponse{Took: resp.TookInMillis, TotalHits: resp.Hits.TotalHits}
+	for _, h := range resp.Hits.Hits {
+		raw, err := json.Marshal(h.Source)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling hit source: %v", err)
+		}
+		out.Hits = append(out.Hits, Hit{ID: h.Id, Type: h.Type, Source: raw})
+	}
+	return out, nil
+}
+
+func (c *v6Client) OpenPIT(ctx context.Context, keepAlive string, indices ...string) (*PITResponse, error) {
+	return nil, fmt.Errorf("esclient: point-in-time is not supported against ES6")
+}
+
+func (c *v6Client) ClosePIT(ctx context.Context, id string) error {
+	return fmt.Errorf("esclient: point-in-time is not supported against ES6")
+}
+
+func (c *v6Client) DeleteByQuery(ctx context.Context, index, query string) error {
+	_, err := c.client.DeleteByQuery(index).
+		Query(es6.NewQueryStringQuery(query)).
+		Do(ctx)
+	return err
+}
@@ -0,0 +1,130 @@
+package esclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// v7Client implements Client on top of olivere/elastic/v7.
+type v7Client struct {
+	client *es7.Client
+}
+
+// NewV7 wraps an existing *elastic.Client (v7) as a Client.
+func NewV7(client *es7.Client) Client {
+	return &v7Client{client: client}
+}
+
+func (c *v7Client) IndexExists(ctx context.Context, index string) (bool, error) {
+	return c.client.IndexExists(index).Do(ctx)
+}
+
+func (c *v7Client) CreateIndex(ctx context.Context, index, body string) error {
+	_, err := c.client.CreateIndex(index).Body(body).Do(ctx)
+	return err
+}
+
+func (c *v7Client) PutMapping(ctx context.Context, index, body string) error {
+	_, err := c.client.PutMapping().Index(index).BodyString(body).Do(ctx)
+	return err
+}
+
+func (c *v7Client) Bulk(ctx context.Context, requests []BulkRequest) (*BulkResponse, error) {
+	svc := c.client.Bulk()
+	for _, req := range requests {
+		svc = svc.Add(toBulkableRequest(req))
+	}
+
+	resp, err := svc.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &BulkResponse{}
+	for _, item := range resp.Items {
+		for _, r := range item {
+			bri := BulkResponseItem{ID: r.Id, Index: r.Index, Status: r.Status}
+			if r.Error != nil {
+				bri.Error = r.Error.Reason
+				out.Failed = append(out.Failed, bri)
+			}
+			out.Items = append(out.Items, bri)
+		}
+	}
+	return out, nil
+}
+
+func toBulkableRequest(req BulkRequest) es7.BulkableRequest {
+	switch req.Op {
+	case BulkDelete:
+		r := es7.NewBulkDeleteRequest().Index(req.Index).Id(req.ID)
+		return r
+	case BulkUpdate:
+		return es7.NewBulkUpdateRequest().Index(req.Index).Id(req.ID).Doc(req.Doc)
+	default:
+		r := es7.NewBulkIndexRequest().Index(req.Index).Doc(req.Doc)
+		if req.ID != "" {
+			r = r.Id(req.ID)
+		}
+		return r
+	}
+}
+
+func (c *v7Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	query := es7.NewBoolQuery()
+	if req.Query != "" {
+		query = query.Filter(es7.NewQueryStringQuery(req.Query))
+	}
+
+	svc := c.client.Search().Query(query).From(req.From).Size(req.Size)
+	if len(req.Indices) > 0 && req.PITID == "" {
+		svc = svc.Index(req.Indices...)
+	}
+	for _, s := range req.Sort {
+		svc = svc.Sort(s, true)
+	}
+	if req.PITID != "" {
+		svc = svc.PointInTime(es7.NewPointInTimeWithKeepAlive(req.PITID, "1m"))
+	}
+	if len(req.SearchAfter) > 0 {
+		svc = svc.SearchAfter(req.SearchAfter...)
+	}
+
+	resp, err := svc.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &SearchResponse{Took: resp.TookInMillis, TotalHits: resp.Hits.TotalHits.Value}
+	for _, h := range resp.Hits.Hits {
+		raw, err := json.Marshal(h.Source)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling hit source: %v", err)
+		}
+		out.Hits = append(out.Hits, Hit{ID: h.Id, Type: h.Type, Source: raw, Sort: h.Sort})
+	}
+	return out, nil
+}
+
+func (c *v7Client) OpenPIT(ctx context.Context, keepAlive string, indices ...string) (*PITResponse, error) {
+	resp, err := c.client.OpenPointInTime(indices...).KeepAlive(keepAlive).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &PITResponse{ID: resp.Id}, nil
+}
+
+func (c *v7Client) ClosePIT(ctx context.Context, id string) error {
+	_, err := c.client.ClosePointInTime().Id(id).Do(ctx)
+	return err
+}
+
+func (c *v7Client) DeleteByQuery(ctx context.Context, index, query string) error {
+	_, err := c.client.DeleteByQuery(index).
+		Query(es7.NewQueryStringQuery(query)).
+		Do(ctx)
+	return err
+}
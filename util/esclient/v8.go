@@ -0,0 +1,231 @@
+package esclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	es8 "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// v8Client implements Client on top of the official low-level
+// go-elasticsearch/v8 client. ES8 removed mapping types entirely, so Type
+// on a BulkRequest is always ignored here.
+type v8Client struct {
+	client *es8.Client
+}
+
+// NewV8 wraps an existing *elasticsearch.Client (v8) as a Client.
+func NewV8(client *es8.Client) Client {
+	return &v8Client{client: client}
+}
+
+func (c *v8Client) do(ctx context.Context, res *esapi.Response, err error, out interface{}) error {
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("esclient: %s", res.String())
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (c *v8Client) IndexExists(ctx context.Context, index string) (bool, error) {
+	res, err := esapi.IndicesExistsRequest{Index: []string{index}}.Do(ctx, c.client)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	return !res.IsError(), nil
+}
+
+func (c *v8Client) CreateIndex(ctx context.Context, index, body string) error {
+	res, err := esapi.IndicesCreateRequest{Index: index, Body: strings.NewReader(body)}.Do(ctx, c.client)
+	return c.do(ctx, res, err, nil)
+}
+
+func (c *v8Client) PutMapping(ctx context.Context, index, body string) error {
+	res, err := esapi.IndicesPutMappingRequest{Index: []string{index}, Body: strings.NewReader(body)}.Do(ctx, c.client)
+	return c.do(ctx, res, err, nil)
+}
+
+func (c *v8Client) Bulk(ctx context.Context, requests []BulkRequest) (*BulkResponse, error) {
+	var buf bytes.Buffer
+	for _, req := range requests {
+		metaFields := map[string]interface{}{"_index": req.Index}
+		if req.ID != "" {
+			metaFields["_id"] = req.ID
+		}
+		meta := map[string]interface{}{string(req.Op): metaFields}
+		metaLine, err := json.Marshal(meta)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(metaLine)
+		buf.WriteByte('\n')
+
+		if req.Op != BulkDelete {
+			body := req.Doc
+			if req.Op == BulkUpdate {
+				body = map[string]interface{}{"doc": req.Doc}
+			}
+			docLine, err := json.Marshal(body)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(docLine)
+			buf.WriteByte('\n')
+		}
+	}
+
+	res, err := esapi.BulkRequest{Body: bytes.NewReader(buf.Bytes())}.Do(ctx, c.client)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("esclient: bulk request failed: %s", res.String())
+	}
+
+	var parsed struct {
+		Items []map[string]struct {
+			ID     string `json:"_id"`
+			Index  string `json:"_index"`
+			Status int    `json:"status"`
+			Error  *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	out := &BulkResponse{}
+	for _, item := range parsed.Items {
+		for _, r := range item {
+			bri := BulkResponseItem{ID: r.ID, Index: r.Index, Status: r.Status}
+			if r.Error != nil {
+				bri.Error = r.Error.Reason
+				out.Failed = append(out.Failed, bri)
+			}
+			out.Items = append(out.Items, bri)
+		}
+	}
+	return out, nil
+}
+
+func (c *v8Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	body := map[string]interface{}{
+		"from": req.From,
+		"size": req.Size,
+	}
+	if req.Query != "" {
+		body["query"] = map[string]interface{}{
+			"query_string": map[string]interface{}{"query": req.Query},
+		}
+	}
+	if len(req.Sort) > 0 {
+		body["sort"] = req.Sort
+	}
+	if req.PITID != "" {
+		body["pit"] = map[string]interface{}{"id": req.PITID, "keep_alive": "1m"}
+	}
+	if len(req.SearchAfter) > 0 {
+		body["search_after"] = req.SearchAfter
+	}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	searchReq := esapi.SearchRequest{Body: bytes.NewReader(raw)}
+	if req.PITID == "" {
+		searchReq.Index = req.Indices
+	}
+
+	res, err := searchReq.Do(ctx, c.client)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("esclient: search failed: %s", res.String())
+	}
+
+	var parsed struct {
+		Took int64 `json:"took"`
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID     string          `json:"_id"`
+				Source json.RawMessage `json:"_source"`
+				Sort   []interface{}   `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	out := &SearchResponse{Took: parsed.Took, TotalHits: parsed.Hits.Total.Value}
+	for _, h := range parsed.Hits.Hits {
+		out.Hits = append(out.Hits, Hit{ID: h.ID, Source: h.Source, Sort: h.Sort})
+	}
+	return out, nil
+}
+
+func (c *v8Client) OpenPIT(ctx context.Context, keepAlive string, indices ...string) (*PITResponse, error) {
+	res, err := esapi.OpenPointInTimeRequest{
+		Index:     indices,
+		KeepAlive: keepAlive,
+	}.Do(ctx, c.client)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("esclient: open point-in-time failed: %s", res.String())
+	}
+
+	var parsed struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	return &PITResponse{ID: parsed.ID}, nil
+}
+
+func (c *v8Client) ClosePIT(ctx context.Context, id string) error {
+	raw, err := json.Marshal(map[string]string{"id": id})
+	if err != nil {
+		return err
+	}
+	res, err := esapi.ClosePointInTimeRequest{Body: bytes.NewReader(raw)}.Do(ctx, c.client)
+	return c.do(ctx, res, err, nil)
+}
+
+func (c *v8Client) DeleteByQuery(ctx context.Context, index, query string) error {
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"query_string": map[string]interface{}{"query": query},
+		},
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	res, err := esapi.DeleteByQueryRequest{Index: []string{index}, Body: bytes.NewReader(raw)}.Do(ctx, c.client)
+	return c.do(ctx, res, err, nil)
+}
@@ -0,0 +1,73 @@
+// Package escursor implements deep-pagination cursors backed by
+// Elasticsearch's Point-In-Time (PIT) API, for callers that need to page
+// reliably past the 10k from/size window.
+package escursor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/esclient"
+)
+
+const logTag = "[escursor]"
+
+// Cursor is the opaque paging state handed back to and accepted from
+// callers: the PIT id plus the sort values of the last hit seen so far.
+type Cursor struct {
+	PITID       string        `json:"pit_id"`
+	SearchAfter []interface{} `json:"search_after,omitempty"`
+}
+
+// Encode serializes c as the opaque cursor string returned to API clients.
+func Encode(c Cursor) (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(raw), nil
+}
+
+// Decode parses a cursor string previously returned by Encode. An empty
+// string decodes to the zero Cursor, representing "start a new PIT".
+func Decode(cursor string) (Cursor, error) {
+	var c Cursor
+	if cursor == "" {
+		return c, nil
+	}
+	raw, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, fmt.Errorf("malformed cursor: %v", err)
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, fmt.Errorf("malformed cursor: %v", err)
+	}
+	return c, nil
+}
+
+// Open starts a new point-in-time against indices, valid for keepAlive.
+// client is the version-agnostic esclient.Client so this works against any
+// cluster version that supports PIT (ES7.10+, ES8); against ES6, client
+// returns an error, which Open passes through unchanged.
+func Open(ctx context.Context, client esclient.Client, keepAlive string, indices ...string) (string, error) {
+	res, err := client.OpenPIT(ctx, keepAlive, indices...)
+	if err != nil {
+		return "", err
+	}
+	return res.ID, nil
+}
+
+// Close releases a point-in-time; failures are logged, not returned, since
+// callers invoke this as best-effort cleanup after the last page.
+func Close(ctx context.Context, client esclient.Client, pitID string) {
+	if pitID == "" {
+		return
+	}
+	if err := client.ClosePIT(ctx, pitID); err != nil {
+		log.Errorln(logTag, ": error closing point-in-time", pitID, ":", err)
+	}
+}
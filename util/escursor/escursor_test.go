@@ -0,0 +1,53 @@
+package escursor
+
+import "testing"
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	c := Cursor{
+		PITID:       "abc123",
+		SearchAfter: []interface{}{float64(42), "tiebreaker"},
+	}
+
+	encoded, err := Encode(c)
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode() error: %v", err)
+	}
+	if decoded.PITID != c.PITID {
+		t.Errorf("PITID = %q, want %q", decoded.PITID, c.PITID)
+	}
+	if len(decoded.SearchAfter) != len(c.SearchAfter) {
+		t.Fatalf("SearchAfter = %v, want %v", decoded.SearchAfter, c.SearchAfter)
+	}
+	for i := range c.SearchAfter {
+		if decoded.SearchAfter[i] != c.SearchAfter[i] {
+			t.Errorf("SearchAfter[%d] = %v, want %v", i, decoded.SearchAfter[i], c.SearchAfter[i])
+		}
+	}
+}
+
+func TestDecodeEmptyCursorIsZeroValue(t *testing.T) {
+	c, err := Decode("")
+	if err != nil {
+		t.Fatalf("Decode(\"\") error: %v", err)
+	}
+	if c.PITID != "" || c.SearchAfter != nil {
+		t.Errorf("Decode(\"\") = %+v, want zero Cursor", c)
+	}
+}
+
+func TestDecodeMalformedCursor(t *testing.T) {
+	cases := []string{
+		"not-valid-base64!!!",
+		"aGVsbG8=", // valid base64, but not JSON
+	}
+	for _, in := range cases {
+		if _, err := Decode(in); err == nil {
+			t.Errorf("Decode(%q) = nil error, want an error", in)
+		}
+	}
+}
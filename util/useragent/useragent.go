@@ -0,0 +1,100 @@
+// Package useragent parses raw User-Agent strings into browser/OS/device
+// classifications for analytics records, caching parsed results since the
+// same handful of UA strings account for most traffic.
+package useragent
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/avct/uasurfer"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// cacheSize bounds how many distinct raw UA strings are kept parsed in
+// memory; real-world traffic has a long tail but a small number of distinct
+// UAs account for the vast majority of requests.
+const cacheSize = 4096
+
+// Info is the parsed, analytics-friendly shape of a User-Agent string.
+type Info struct {
+	BrowserFamily  string `json:"browser_family"`
+	BrowserVersion string `json:"browser_version"`
+	OSFamily       string `json:"os_family"`
+	OSVersion      string `json:"os_version"`
+	DeviceType     string `json:"device_type"`
+}
+
+// Parser parses raw User-Agent strings, caching results by the raw string.
+type Parser struct {
+	cache *lru.Cache
+}
+
+// New creates a Parser with its own LRU cache.
+func New() (*Parser, error) {
+	cache, err := lru.New(cacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Parser{cache: cache}, nil
+}
+
+var (
+	instance *Parser
+	once     sync.Once
+)
+
+// Instance returns the package-level Parser shared by all callers.
+func Instance() *Parser {
+	once.Do(func() {
+		p, err := New()
+		if err != nil {
+			// cacheSize is a positive constant, lru.New only errors on size <= 0.
+			panic(fmt.Sprintf("useragent: failed to create cache: %v", err))
+		}
+		instance = p
+	})
+	return instance
+}
+
+// Parse classifies raw, a User-Agent header value. The zero Info is
+// returned for an empty string.
+func (p *Parser) Parse(raw string) Info {
+	if raw == "" {
+		return Info{}
+	}
+	if cached, ok := p.cache.Get(raw); ok {
+		return cached.(Info)
+	}
+
+	ua := uasurfer.Parse(raw)
+	info := Info{
+		BrowserFamily:  ua.Browser.Name.String(),
+		BrowserVersion: formatVersion(ua.Browser.Version),
+		OSFamily:       ua.OS.Name.String(),
+		OSVersion:      formatVersion(ua.OS.Version),
+		DeviceType:     deviceType(ua),
+	}
+
+	p.cache.Add(raw, info)
+	return info
+}
+
+func formatVersion(v uasurfer.Version) string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func deviceType(ua *uasurfer.UserAgent) string {
+	switch ua.DeviceType {
+	case uasurfer.DeviceTablet:
+		return "tablet"
+	case uasurfer.DevicePhone:
+		return "phone"
+	case uasurfer.DeviceComputer:
+		return "desktop"
+	case uasurfer.DeviceBot:
+		return "bot"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,82 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/avct/uasurfer"
+)
+
+func TestParseEmpty(t *testing.T) {
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if got := p.Parse(""); got != (Info{}) {
+		t.Errorf("Parse(\"\") = %+v, want zero Info", got)
+	}
+}
+
+func TestParseNonEmptyIsClassified(t *testing.T) {
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	const chromeOnMac = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 " +
+		"(KHTML, like Gecko) Chrome/115.0.0.0 Safari/537.36"
+
+	info := p.Parse(chromeOnMac)
+	if info == (Info{}) {
+		t.Errorf("Parse(%q) returned a zero Info, want a classified one", chromeOnMac)
+	}
+	if info.DeviceType != "desktop" {
+		t.Errorf("DeviceType = %q, want desktop", info.DeviceType)
+	}
+}
+
+func TestParseCachesByRawString(t *testing.T) {
+	p, err := New()
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	const ua = "some-client/1.0"
+	first := p.Parse(ua)
+	if p.cache.Len() != 1 {
+		t.Fatalf("cache.Len() = %d, want 1 after first Parse", p.cache.Len())
+	}
+
+	second := p.Parse(ua)
+	if first != second {
+		t.Errorf("Parse(%q) returned different results across calls: %+v vs %+v", ua, first, second)
+	}
+	if p.cache.Len() != 1 {
+		t.Errorf("cache.Len() = %d, want still 1 after a cache hit", p.cache.Len())
+	}
+}
+
+func TestFormatVersion(t *testing.T) {
+	got := formatVersion(uasurfer.Version{Major: 1, Minor: 2, Patch: 3})
+	if want := "1.2.3"; got != want {
+		t.Errorf("formatVersion() = %q, want %q", got, want)
+	}
+}
+
+func TestDeviceTypeMapping(t *testing.T) {
+	cases := []struct {
+		in   uasurfer.DeviceType
+		want string
+	}{
+		{uasurfer.DeviceTablet, "tablet"},
+		{uasurfer.DevicePhone, "phone"},
+		{uasurfer.DeviceComputer, "desktop"},
+		{uasurfer.DeviceBot, "bot"},
+		{uasurfer.DeviceUnknown, "unknown"},
+	}
+	for _, c := range cases {
+		ua := &uasurfer.UserAgent{DeviceType: c.in}
+		if got := deviceType(ua); got != c.want {
+			t.Errorf("deviceType(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}